@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/crowdmob/goamz/aws"
+
+	"github.com/ianfoo/aws-tools/pkg/sqsclient"
+)
+
+const (
+	defAttrQueue = "message_queue"
+	defAttrName  = "ApproximateNumberOfMessages"
+)
+
+type attrRow struct {
+	Region string `json:"region"`
+	Queue  string `json:"queue"`
+	Attr   string `json:"attribute"`
+	Value  string `json:"value"`
+}
+
+// runAttr fetches queue attributes, fanning out across regions
+// concurrently when -r names more than one (or "all").
+func runAttr(args []string) {
+	fs := flag.NewFlagSet("attr", flag.ExitOnError)
+	queueList := fs.String("q", defAttrQueue, "Queue names (comma-separated)")
+	attrList := fs.String("a", defAttrName, "Attribute names (comma-separated)")
+	regionList := fs.String("r", "us-east-1", `Region(s), comma-separated, or "all"`)
+	output := fs.String("o", "table", "Output format: table, json, csv")
+	fs.Parse(args)
+
+	auth, err := sqsclient.Auth()
+	fatal(err)
+
+	rows := fanOutAttrs(auth, regionsFor(*regionList), strings.Split(*queueList, ","), strings.Split(*attrList, ","))
+	printAttrRows(rows, *output)
+}
+
+func regionsFor(spec string) []string {
+	if spec == "all" {
+		return sqsclient.AllRegions
+	}
+	return strings.Split(spec, ",")
+}
+
+func fanOutAttrs(auth aws.Auth, regions, queues, attrs []string) []attrRow {
+	var (
+		mu   sync.Mutex
+		rows []attrRow
+		wg   sync.WaitGroup
+	)
+	for _, region := range regions {
+		region := region
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := sqsclient.New(auth, region)
+			if err != nil {
+				fmt.Printf("error connecting to %s: %v\n", region, err)
+				return
+			}
+			for _, qn := range queues {
+				q, err := client.GetQueue(qn)
+				if err != nil {
+					fmt.Printf("error for queue %s in %s: %v\n", qn, region, err)
+					continue
+				}
+				for _, an := range attrs {
+					a, err := q.GetQueueAttributes(an)
+					if err != nil {
+						fmt.Printf("error getting attribute %s for queue %s in %s: %v\n", an, qn, region, err)
+						continue
+					}
+					mu.Lock()
+					rows = append(rows, attrRow{
+						Region: client.Region,
+						Queue:  qn,
+						Attr:   a.Attributes[0].Name,
+						Value:  a.Attributes[0].Value,
+					})
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return rows
+}
+
+func printAttrRows(rows []attrRow, format string) {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(rows)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"region", "queue", "attribute", "value"})
+		for _, r := range rows {
+			w.Write([]string{r.Region, r.Queue, r.Attr, r.Value})
+		}
+		w.Flush()
+	default:
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "REGION\tQUEUE\tATTRIBUTE\tVALUE")
+		for _, r := range rows {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Region, r.Queue, r.Attr, r.Value)
+		}
+		tw.Flush()
+	}
+}
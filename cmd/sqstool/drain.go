@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/crowdmob/goamz/sqs"
+
+	"github.com/ianfoo/aws-tools/pkg/sqsclient"
+)
+
+const drainBatchSize = 10
+
+// runDrain receives and deletes messages from a queue, optionally
+// redriving each one to a destination queue (-to) before deleting it
+// from the source, and optionally just reporting what it would do
+// (-dry-run) without deleting or redriving anything.
+func runDrain(args []string) {
+	drain(args, "drain", false)
+}
+
+// runMove is drain with -to required: the common case of moving every
+// message on a queue (e.g. a DLQ) over to another one.
+func runMove(args []string) {
+	drain(args, "move", true)
+}
+
+func drain(args []string, name string, requireTo bool) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	queueName := fs.String("q", "", "Name of queue to drain")
+	region := fs.String("r", "", `Queue region (e.g., "us-east-1", "usw01")`)
+	toQueueName := fs.String("to", "", "Redrive received messages to this queue instead of just deleting them")
+	maxMessages := fs.Int("max-messages", 0, "Stop after this many messages (default: drain until the queue is empty)")
+	visibilityTimeout := fs.Int("visibility-timeout", 30, "Visibility timeout (seconds) for received messages")
+	dryRun := fs.Bool("dry-run", false, "Report what would be drained/redriven without deleting or sending anything")
+	fs.Parse(args)
+
+	if *queueName == "" || *region == "" || (requireTo && *toQueueName == "") {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	auth, err := sqsclient.Auth()
+	fatal(err)
+	client, err := sqsclient.New(auth, *region)
+	fatal(err)
+	from, err := client.GetQueue(*queueName)
+	fatal(err)
+
+	var to *sqs.Queue
+	if *toQueueName != "" {
+		to, err = client.GetQueue(*toQueueName)
+		fatal(err)
+	}
+
+	drained := 0
+	for *maxMessages <= 0 || drained < *maxMessages {
+		n := drainBatchSize
+		if *maxMessages > 0 && *maxMessages-drained < n {
+			n = *maxMessages - drained
+		}
+		resp, err := from.ReceiveMessageWithVisibilityTimeout(n, *visibilityTimeout)
+		if err != nil {
+			fmt.Println("Error receiving messages:", err)
+			break
+		}
+		if len(resp.Messages) == 0 {
+			break
+		}
+		for _, m := range resp.Messages {
+			if *dryRun {
+				fmt.Printf("[dry-run] would drain message %s\n", m.MessageId)
+				drained++
+				continue
+			}
+			if to != nil {
+				if _, err := to.SendMessageBatch([]sqs.Message{{MessageId: m.MessageId, Body: m.Body}}); err != nil {
+					fmt.Printf("Error redriving message %s, leaving it on the source queue: %v\n", m.MessageId, err)
+					continue
+				}
+			}
+			if _, err := from.DeleteMessage(&m); err != nil {
+				fmt.Printf("Error deleting message %s from source queue: %v\n", m.MessageId, err)
+				continue
+			}
+			drained++
+		}
+	}
+	fmt.Printf("drained %d messages\n", drained)
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"time"
+
+	"github.com/crowdmob/goamz/sqs"
+
+	"github.com/ianfoo/aws-tools/pkg/sqsclient"
+)
+
+const (
+	fillDefaultCount      = 1000
+	fillInterval          = 1000
+	fillBatchSize         = 10
+	fillDefaultMsgTmpl    = "Message_Body"
+	fillDefaultDurWorkers = 10
+)
+
+// fillConfig holds fill's -flag settings (other than -q, -r and -f,
+// which runFill resolves itself) plus the runtime state (queue handle,
+// rate limiter, metrics) that its workers share.
+type fillConfig struct {
+	queue *sqs.Queue
+
+	count       int
+	serialMode  bool
+	msgBodyTmpl string
+
+	walPath       string
+	walFsyncEvery int
+	replayPath    string
+	walCh         chan<- walRecord
+
+	genKind         string
+	seed            int64
+	genCorpus       string
+	genLinesMode    string
+	genJSONTemplate string
+	genSize         int
+	genSizeDist     string
+	genSizeMin      int
+	genSizeMax      int
+	genSizeMean     float64
+	genSizeStddev   float64
+	genZipfS        float64
+	genZipfV        float64
+
+	rate         float64
+	burst        int
+	fillDuration time.Duration
+	concurrency  int
+	metricsAddr  string
+	limiter      *tokenBucket
+
+	metrics *fillMetrics
+}
+
+// runFill sends (or replays) messages into an SQS queue. It is the
+// sqstool successor to the old standalone sqsfill binary.
+func runFill(args []string) {
+	fs := flag.NewFlagSet("fill", flag.ExitOnError)
+	var (
+		queueName       = fs.String("q", "", "Name of queue to fill")
+		region          = fs.String("r", "", `Queue region (e.g., "us-east-1", "usw01")`)
+		msgBodyTmplFile = fs.String("f", "", "Read message body template from file")
+	)
+	cfg := &fillConfig{metrics: newFillMetrics()}
+	fs.StringVar(&cfg.msgBodyTmpl, "b", fillDefaultMsgTmpl, "Message body template")
+	fs.IntVar(&cfg.count, "c", fillDefaultCount, "Number of messages to insert")
+	fs.BoolVar(&cfg.serialMode, "serial", false, "Fill queue non-concurrently")
+	fs.StringVar(&cfg.walPath, "wal", "", "Append a write-ahead log of sent messages to this path")
+	fs.IntVar(&cfg.walFsyncEvery, "wal-fsync-every", defaultWALFsyncEvery, "Fsync the WAL after this many records")
+	fs.StringVar(&cfg.replayPath, "replay", "", "Replay an existing WAL, resending any unacked messages, then exit")
+	fs.StringVar(&cfg.genKind, "gen", genTemplate, "Message body generator: template, file-lines, json-schema, sized, zipf")
+	fs.Int64Var(&cfg.seed, "seed", 1, "Master seed for generator randomness")
+	fs.StringVar(&cfg.genCorpus, "gen-corpus", "", "Corpus file for the file-lines and zipf generators")
+	fs.StringVar(&cfg.genLinesMode, "gen-lines-mode", linesModeRoundRobin, "file-lines sampling mode: roundrobin or random")
+	fs.StringVar(&cfg.genJSONTemplate, "gen-json-template", "", "Template file for the json-schema generator")
+	fs.IntVar(&cfg.genSize, "gen-size", 1024, "Target body size in bytes for the sized generator, fixed distribution")
+	fs.StringVar(&cfg.genSizeDist, "gen-size-dist", sizeDistFixed, "Size distribution for the sized generator: fixed, uniform, lognormal")
+	fs.IntVar(&cfg.genSizeMin, "gen-size-min", 0, "Minimum body size for the sized generator's uniform distribution")
+	fs.IntVar(&cfg.genSizeMax, "gen-size-max", 0, "Maximum body size for the sized generator's uniform distribution")
+	fs.Float64Var(&cfg.genSizeMean, "gen-size-mean", 0, "Mean body size for the sized generator's lognormal distribution")
+	fs.Float64Var(&cfg.genSizeStddev, "gen-size-stddev", 0, "Body size standard deviation for the sized generator's lognormal distribution")
+	fs.Float64Var(&cfg.genZipfS, "gen-zipf-s", 1.1, "Zipf distribution exponent for the zipf generator")
+	fs.Float64Var(&cfg.genZipfV, "gen-zipf-v", 1, "Zipf distribution offset for the zipf generator")
+	fs.Float64Var(&cfg.rate, "rate", 0, "Send rate limit in messages/sec across all workers (default unlimited)")
+	fs.IntVar(&cfg.burst, "burst", 0, "Token bucket burst size (default: same as -rate)")
+	fs.DurationVar(&cfg.fillDuration, "duration", 0, "Run as a closed-loop load generator for this long instead of sending -c messages")
+	fs.IntVar(&cfg.concurrency, "workers", 0, "Number of concurrent workers (default: derived from -c, or 10 with -duration)")
+	fs.StringVar(&cfg.metricsAddr, "metrics-addr", "", "Serve live metrics in Prometheus text format on this address")
+	fs.Parse(args)
+
+	if *queueName == "" || *region == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if cfg.replayPath == "" && cfg.genKind == genTemplate && cfg.msgBodyTmpl == "" && *msgBodyTmplFile == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if cfg.fillDuration > 0 && cfg.serialMode {
+		fmt.Println("-duration requires concurrent mode; -serial is not supported")
+		os.Exit(1)
+	}
+	if cfg.genKind == genZipf && (cfg.genZipfS <= 1.0 || cfg.genZipfV < 1.0) {
+		fmt.Println("-gen-zipf-s must be > 1 and -gen-zipf-v must be >= 1")
+		os.Exit(1)
+	}
+	if cfg.genKind == genSized && cfg.genSizeDist == sizeDistUniform && cfg.genSizeMin > cfg.genSizeMax {
+		fmt.Println("-gen-size-min must be <= -gen-size-max")
+		os.Exit(1)
+	}
+	if cfg.genKind == genSized && cfg.genSizeDist == sizeDistLognormal && cfg.genSizeMean <= 0 {
+		fmt.Println("-gen-size-mean must be > 0 for -gen-size-dist lognormal")
+		os.Exit(1)
+	}
+	if *msgBodyTmplFile != "" {
+		body, err := ioutil.ReadFile(*msgBodyTmplFile)
+		fatal(err)
+		cfg.msgBodyTmpl = string(body)
+	}
+	if cfg.rate > 0 {
+		cfg.limiter = newTokenBucket(cfg.rate, cfg.burst)
+	}
+
+	auth, err := sqsclient.Auth()
+	fatal(err)
+	client, err := sqsclient.New(auth, *region)
+	fatal(err)
+	cfg.queue, err = client.GetQueue(*queueName)
+	fatal(err)
+
+	if cfg.replayPath != "" {
+		cfg.replayWAL(cfg.replayPath)
+		return
+	}
+
+	if cfg.metricsAddr != "" {
+		go serveMetrics(cfg.metricsAddr, cfg.metrics)
+	}
+	reportDone := make(chan struct{})
+	go reportEverySecond(cfg.metrics, reportDone)
+	defer close(reportDone)
+
+	if cfg.walPath != "" {
+		f, err := os.OpenFile(cfg.walPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		fatal(err)
+		defer f.Close()
+		recs := make(chan walRecord)
+		errCh := make(chan error)
+		go walWriter(recs, f, cfg.walFsyncEvery, errCh)
+		walDone := make(chan struct{})
+		go func() {
+			for err := range errCh {
+				fmt.Println("WAL write error:", err)
+			}
+			close(walDone)
+		}()
+		cfg.walCh = recs
+		// Wait for walWriter's final flush before the file is closed.
+		defer func() {
+			close(recs)
+			<-walDone
+		}()
+	}
+
+	if cfg.serialMode {
+		cfg.fillSerially()
+	} else {
+		cfg.fillConcurrent()
+	}
+}
+
+func (c *fillConfig) fillSerially() {
+	gen := c.newBodyGenerator(0)
+	total := c.count
+	remaining := c.count
+	for remaining > 0 {
+		batchSize := fillBatchSize
+		if remaining%fillBatchSize != 0 {
+			batchSize = remaining % fillBatchSize
+		}
+		batch := genMessageBatch(gen, batchSize)
+		c.sendBatchWithWAL(batch, c.walCh)
+		remaining -= len(batch)
+		if remaining%1000 == 0 {
+			fmt.Println(total-remaining, "messages sent")
+		}
+	}
+}
+
+// sendBatchWithWAL sends batch, logging each message to the WAL (if recs
+// is non-nil) as unacked beforehand and acked once the send succeeds. If
+// a rate limiter is configured it blocks until the batch is allowed to
+// go out, and every send is timed and counted for the metrics reporter.
+func (c *fillConfig) sendBatchWithWAL(batch []sqs.Message, recs chan<- walRecord) {
+	if c.limiter != nil {
+		c.limiter.take(len(batch))
+	}
+	if recs != nil {
+		for _, m := range batch {
+			recs <- walRecord{ID: m.MessageId, Body: m.Body, TS: time.Now().Unix()}
+		}
+	}
+	start := time.Now()
+	_, err := c.queue.SendMessageBatch(batch)
+	c.metrics.recordBatch(len(batch), time.Since(start), errorCode(err))
+	if err != nil {
+		fmt.Println("Error sending message batch:", err)
+		return
+	}
+	if recs != nil {
+		for _, m := range batch {
+			recs <- walRecord{ID: m.MessageId, Body: m.Body, TS: time.Now().Unix(), Ack: true}
+		}
+	}
+}
+
+type fillPartial struct {
+	id    int
+	count int
+}
+
+func (c *fillConfig) fillConcurrent() {
+	if c.fillDuration > 0 {
+		c.fillConcurrentForDuration()
+		return
+	}
+	countCh := make(chan fillPartial)
+	workers := c.concurrency
+	if workers <= 0 {
+		workers = int(math.Ceil(float64(c.count) / float64(fillInterval)))
+	}
+	for i := 0; i < workers; i++ {
+		numMsgs := c.count / workers
+		if i < c.count%workers {
+			numMsgs++
+		}
+		go c.fillSection(i+1, numMsgs, countCh)
+	}
+	total := 0
+	for i := 0; i < workers; i++ {
+		chunk := <-countCh
+		total += chunk.count
+		fmt.Printf("[worker %02d] sent %d messages; total: %d\n", chunk.id, chunk.count, total)
+	}
+}
+
+func (c *fillConfig) fillSection(id, msgCount int, reportCh chan<- fillPartial) {
+	gen := c.newBodyGenerator(id)
+	sent := 0
+	for sent < msgCount {
+		batchSize := fillBatchSize
+		if msgCount-sent < fillBatchSize {
+			batchSize = msgCount - sent
+		}
+		batch := genMessageBatch(gen, batchSize)
+		c.sendBatchWithWAL(batch, c.walCh)
+		sent += len(batch)
+	}
+	reportCh <- fillPartial{id, sent}
+}
+
+// fillConcurrentForDuration runs fill as a closed-loop load generator: a
+// fixed number of workers send batches as fast as the rate limiter
+// allows until -duration elapses, rather than stopping at a fixed count.
+func (c *fillConfig) fillConcurrentForDuration() {
+	workers := c.concurrency
+	if workers <= 0 {
+		workers = fillDefaultDurWorkers
+	}
+	deadline := time.Now().Add(c.fillDuration)
+	countCh := make(chan fillPartial)
+	for i := 0; i < workers; i++ {
+		go c.fillUntil(i+1, deadline, countCh)
+	}
+	total := 0
+	for i := 0; i < workers; i++ {
+		chunk := <-countCh
+		total += chunk.count
+		fmt.Printf("[worker %02d] sent %d messages; total: %d\n", chunk.id, chunk.count, total)
+	}
+}
+
+func (c *fillConfig) fillUntil(id int, deadline time.Time, reportCh chan<- fillPartial) {
+	gen := c.newBodyGenerator(id)
+	sent := 0
+	for time.Now().Before(deadline) {
+		batch := genMessageBatch(gen, fillBatchSize)
+		c.sendBatchWithWAL(batch, c.walCh)
+		sent += len(batch)
+	}
+	reportCh <- fillPartial{id, sent}
+}
+
+func genMessageBatch(gen BodyGenerator, batchSize int) []sqs.Message {
+	var (
+		msgs = make([]sqs.Message, batchSize)
+		buf  = new(bytes.Buffer)
+	)
+	for i := range msgs {
+		msgs[i] = gen.Next(buf)
+	}
+	return msgs
+}
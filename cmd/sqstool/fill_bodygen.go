@@ -0,0 +1,250 @@
+// Pluggable message body generators for sqstool's fill subcommand,
+// selected with -gen. Each worker gets its own BodyGenerator instance,
+// seeded deterministically from the master -seed plus the worker's id,
+// so a fill's random choices are reproducible run-to-run but workers
+// never share (and therefore never contend on) a single rand.Rand.
+// Message IDs and timestamps include the current time and so differ
+// between runs.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/crowdmob/goamz/sqs"
+)
+
+const (
+	genTemplate   = "template"
+	genFileLines  = "file-lines"
+	genJSONSchema = "json-schema"
+	genSized      = "sized"
+	genZipf       = "zipf"
+
+	sizeDistFixed     = "fixed"
+	sizeDistUniform   = "uniform"
+	sizeDistLognormal = "lognormal"
+
+	linesModeRoundRobin = "roundrobin"
+	linesModeRandom     = "random"
+)
+
+// BodyGenerator produces one message at a time, writing scratch work into
+// buf (which it must Reset before returning) and handing back the
+// finished message.
+type BodyGenerator interface {
+	Next(buf *bytes.Buffer) sqs.Message
+}
+
+// newBodyGenerator builds the BodyGenerator selected by -gen for worker
+// id, seeding its private rand.Rand from the master seed and the worker
+// id so each worker's stream is distinct but reproducible.
+func (c *fillConfig) newBodyGenerator(id int) BodyGenerator {
+	r := rand.New(rand.NewSource(c.seed + int64(id)))
+	switch c.genKind {
+	case genFileLines:
+		return &fileLinesGenerator{lines: loadCorpusLines(c.genCorpus), mode: c.genLinesMode, r: r}
+	case genJSONSchema:
+		return &jsonSchemaGenerator{tmpl: loadJSONTemplate(c.genJSONTemplate), r: r}
+	case genSized:
+		return &sizedGenerator{dist: c.genSizeDist, size: c.genSize, min: c.genSizeMin, max: c.genSizeMax,
+			mean: c.genSizeMean, stddev: c.genSizeStddev, r: r}
+	case genZipf:
+		lines := loadCorpusLines(c.genCorpus)
+		return &zipfGenerator{
+			lines: lines,
+			z:     rand.NewZipf(r, c.genZipfS, c.genZipfV, uint64(len(lines)-1)),
+			r:     r,
+		}
+	default:
+		return &templateGenerator{tmpl: c.msgBodyTmpl, r: r}
+	}
+}
+
+func nextMessageID(r *rand.Rand) string {
+	return "ID_" + strconv.FormatInt(time.Now().UnixNano(), 10) + "_" + strconv.Itoa(r.Intn(1000))
+}
+
+// templateGenerator reproduces fill's original behavior: the message
+// body is the configured template with a unique ID prepended.
+type templateGenerator struct {
+	tmpl string
+	r    *rand.Rand
+}
+
+func (g *templateGenerator) Next(buf *bytes.Buffer) sqs.Message {
+	defer buf.Reset()
+	mID := nextMessageID(g.r)
+	buf.WriteString(mID)
+	buf.WriteByte(' ')
+	buf.WriteString(g.tmpl)
+	return sqs.Message{MessageId: mID, Body: buf.String()}
+}
+
+// fileLinesGenerator draws bodies from a corpus file, one line per
+// message, either round-robin or by random sampling.
+type fileLinesGenerator struct {
+	lines []string
+	mode  string
+	next  int
+	r     *rand.Rand
+}
+
+func (g *fileLinesGenerator) Next(buf *bytes.Buffer) sqs.Message {
+	defer buf.Reset()
+	var line string
+	if g.mode == linesModeRandom {
+		line = g.lines[g.r.Intn(len(g.lines))]
+	} else {
+		line = g.lines[g.next%len(g.lines)]
+		g.next++
+	}
+	mID := nextMessageID(g.r)
+	buf.WriteString(line)
+	return sqs.Message{MessageId: mID, Body: buf.String()}
+}
+
+// jsonSchemaGenerator fills a user-supplied text/template with faker
+// fields (uuid, timestamp, ip, name) to produce structured JSON bodies.
+type jsonSchemaGenerator struct {
+	tmpl *template.Template
+	r    *rand.Rand
+}
+
+func (g *jsonSchemaGenerator) Next(buf *bytes.Buffer) sqs.Message {
+	defer buf.Reset()
+	mID := nextMessageID(g.r)
+	if err := g.tmpl.Funcs(fakerFuncs(g.r)).Execute(buf, nil); err != nil {
+		fmt.Println("Error executing json-schema template:", err)
+	}
+	return sqs.Message{MessageId: mID, Body: buf.String()}
+}
+
+func fakerFuncs(r *rand.Rand) template.FuncMap {
+	names := []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi"}
+	return template.FuncMap{
+		"uuid": func() string {
+			b := make([]byte, 16)
+			r.Read(b)
+			b[6] = (b[6] & 0x0f) | 0x40
+			b[8] = (b[8] & 0x3f) | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+		},
+		"timestamp": func() string {
+			return time.Now().UTC().Format(time.RFC3339Nano)
+		},
+		"ip": func() string {
+			return fmt.Sprintf("%d.%d.%d.%d", r.Intn(256), r.Intn(256), r.Intn(256), r.Intn(256))
+		},
+		"name": func() string {
+			return names[r.Intn(len(names))]
+		},
+	}
+}
+
+// sizedGenerator produces bodies of a target byte size drawn from a
+// configurable distribution, to model realistic production payload
+// sizes rather than a single fixed body. Every body starts with the
+// message ID and a space, so a target shorter than that prefix yields a
+// body longer than the target.
+type sizedGenerator struct {
+	dist         string
+	size         int
+	min, max     int
+	mean, stddev float64
+	r            *rand.Rand
+}
+
+func (g *sizedGenerator) targetSize() int {
+	switch g.dist {
+	case sizeDistUniform:
+		return g.min + g.r.Intn(g.max-g.min+1)
+	case sizeDistLognormal:
+		// Convert the desired arithmetic mean/stddev (in bytes) to the
+		// mu/sigma of the underlying normal distribution.
+		variance := g.stddev * g.stddev
+		mu := math.Log(g.mean * g.mean / math.Sqrt(variance+g.mean*g.mean))
+		sigma := math.Sqrt(math.Log(1 + variance/(g.mean*g.mean)))
+		size := int(math.Exp(mu + sigma*g.r.NormFloat64()))
+		if size < 1 {
+			size = 1
+		}
+		return size
+	default:
+		return g.size
+	}
+}
+
+func (g *sizedGenerator) Next(buf *bytes.Buffer) sqs.Message {
+	defer buf.Reset()
+	mID := nextMessageID(g.r)
+	buf.WriteString(mID)
+	buf.WriteByte(' ')
+	n := g.targetSize()
+	for buf.Len() < n {
+		buf.WriteByte(byte('a' + g.r.Intn(26)))
+	}
+	return sqs.Message{MessageId: mID, Body: buf.String()}
+}
+
+// zipfGenerator samples bodies from a corpus with Zipfian frequency, so
+// a small number of bodies dominate the stream the way "hot" payloads
+// do in real production traffic.
+type zipfGenerator struct {
+	lines []string
+	z     *rand.Zipf
+	r     *rand.Rand
+}
+
+func (g *zipfGenerator) Next(buf *bytes.Buffer) sqs.Message {
+	defer buf.Reset()
+	mID := nextMessageID(g.r)
+	buf.WriteString(g.lines[g.z.Uint64()])
+	return sqs.Message{MessageId: mID, Body: buf.String()}
+}
+
+func loadCorpusLines(path string) []string {
+	if path == "" {
+		fmt.Println("Error: -gen-corpus is required for this -gen mode")
+		os.Exit(1)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Println("Error reading corpus file:", err)
+		os.Exit(1)
+	}
+	lines := strings.Split(strings.TrimRight(string(contents), "\n"), "\n")
+	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
+		fmt.Println("Error: corpus file is empty")
+		os.Exit(1)
+	}
+	return lines
+}
+
+func loadJSONTemplate(path string) *template.Template {
+	if path == "" {
+		fmt.Println("Error: -gen-json-template is required for -gen json-schema")
+		os.Exit(1)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Println("Error reading json-schema template:", err)
+		os.Exit(1)
+	}
+	// The faker funcs must be known at parse time; each generator binds
+	// them to its own rand.Rand before executing.
+	tmpl, err := template.New("json-schema").Funcs(fakerFuncs(nil)).Parse(string(contents))
+	if err != nil {
+		fmt.Println("Error parsing json-schema template:", err)
+		os.Exit(1)
+	}
+	return tmpl
+}
@@ -0,0 +1,157 @@
+// Live throughput metrics for fill (serial or concurrent): a per-second rate
+// report, streaming batch-latency percentiles, and error counts by AWS
+// error code. When -metrics-addr is set, the same numbers are also
+// served in Prometheus text format so a running fill can be scraped by
+// an external dashboard.
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/crowdmob/goamz/sqs"
+)
+
+const reservoirSize = 10000
+
+// fillMetrics accumulates counters and a latency reservoir sample that
+// are safe to update from any number of concurrent workers.
+type fillMetrics struct {
+	sent      int64 // atomic
+	lastSent  int64 // atomic; messages sent as of the previous tick
+	batches   int64 // atomic
+	latencies struct {
+		mu      sync.Mutex
+		samples []time.Duration
+		seen    int64
+	}
+	errors struct {
+		mu     sync.Mutex
+		counts map[string]int64
+	}
+}
+
+func newFillMetrics() *fillMetrics {
+	m := &fillMetrics{}
+	m.errors.counts = make(map[string]int64)
+	return m
+}
+
+func (m *fillMetrics) recordBatch(n int, latency time.Duration, errCode string) {
+	atomic.AddInt64(&m.sent, int64(n))
+	atomic.AddInt64(&m.batches, 1)
+	m.recordLatency(latency)
+	if errCode != "" {
+		m.errors.mu.Lock()
+		m.errors.counts[errCode]++
+		m.errors.mu.Unlock()
+	}
+}
+
+// recordLatency keeps a fixed-size reservoir sample of batch latencies
+// using Algorithm R, so percentile estimates stay cheap even across a
+// very long-running fill.
+func (m *fillMetrics) recordLatency(d time.Duration) {
+	m.latencies.mu.Lock()
+	defer m.latencies.mu.Unlock()
+	m.latencies.seen++
+	if len(m.latencies.samples) < reservoirSize {
+		m.latencies.samples = append(m.latencies.samples, d)
+		return
+	}
+	if j := rand.Int63n(m.latencies.seen); j < reservoirSize {
+		m.latencies.samples[j] = d
+	}
+}
+
+func (m *fillMetrics) percentiles() (p50, p95, p99 time.Duration) {
+	m.latencies.mu.Lock()
+	samples := append([]time.Duration(nil), m.latencies.samples...)
+	m.latencies.mu.Unlock()
+	if len(samples) == 0 {
+		return 0, 0, 0
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	pick := func(p float64) time.Duration {
+		i := int(p * float64(len(samples)-1))
+		return samples[i]
+	}
+	return pick(0.50), pick(0.95), pick(0.99)
+}
+
+func (m *fillMetrics) errorCounts() map[string]int64 {
+	m.errors.mu.Lock()
+	defer m.errors.mu.Unlock()
+	out := make(map[string]int64, len(m.errors.counts))
+	for k, v := range m.errors.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// reportEverySecond prints m's current send rate, latency percentiles,
+// and error counts once per second until done is closed.
+func reportEverySecond(m *fillMetrics, done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			printMetricsSnapshot(m)
+		case <-done:
+			return
+		}
+	}
+}
+
+func printMetricsSnapshot(m *fillMetrics) {
+	total := atomic.LoadInt64(&m.sent)
+	last := atomic.LoadInt64(&m.lastSent)
+	atomic.StoreInt64(&m.lastSent, total)
+	p50, p95, p99 := m.percentiles()
+	fmt.Printf("[metrics] rate=%d msg/s total=%d p50=%s p95=%s p99=%s",
+		total-last, total, p50, p95, p99)
+	for code, n := range m.errorCounts() {
+		fmt.Printf(" err[%s]=%d", code, n)
+	}
+	fmt.Println()
+}
+
+// serveMetrics exposes m at addr in Prometheus text exposition format.
+func serveMetrics(addr string, m *fillMetrics) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		total := atomic.LoadInt64(&m.sent)
+		batches := atomic.LoadInt64(&m.batches)
+		p50, p95, p99 := m.percentiles()
+		fmt.Fprintf(w, "sqstool_fill_messages_sent_total %d\n", total)
+		fmt.Fprintf(w, "sqstool_fill_batches_sent_total %d\n", batches)
+		fmt.Fprintf(w, "sqstool_fill_batch_latency_seconds{quantile=\"0.5\"} %f\n", p50.Seconds())
+		fmt.Fprintf(w, "sqstool_fill_batch_latency_seconds{quantile=\"0.95\"} %f\n", p95.Seconds())
+		fmt.Fprintf(w, "sqstool_fill_batch_latency_seconds{quantile=\"0.99\"} %f\n", p99.Seconds())
+		for code, n := range m.errorCounts() {
+			fmt.Fprintf(w, "sqstool_fill_errors_total{code=%q} %d\n", code, n)
+		}
+	})
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fmt.Println("Error serving metrics:", err)
+	}
+}
+
+// errorCode extracts an AWS error code from err, falling back to a
+// generic bucket when the error isn't one the SQS client attaches a
+// code to.
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	if awsErr, ok := err.(*sqs.Error); ok && awsErr.Code != "" {
+		return awsErr.Code
+	}
+	return "unknown"
+}
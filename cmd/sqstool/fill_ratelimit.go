@@ -0,0 +1,75 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket is a simple token-bucket rate limiter shared across all
+// fill workers, so the aggregate send rate stays near -rate msg/s
+// regardless of how many workers are running.
+type tokenBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	rate     float64 // tokens added per second
+	last     time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	capacity := float64(burst)
+	if capacity <= 0 {
+		capacity = rate
+	}
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &tokenBucket{
+		tokens:   capacity,
+		capacity: capacity,
+		rate:     rate,
+		last:     time.Now(),
+	}
+}
+
+// take blocks until n tokens are available, then consumes them. n may
+// exceed the bucket's capacity (a batch can be larger than -burst), so
+// take draws at most a capacity's worth at a time and repeats until the
+// full amount has been consumed, rather than waiting forever for a
+// single reserve call that can never be satisfied.
+func (b *tokenBucket) take(n int) {
+	remaining := float64(n)
+	for remaining > 0 {
+		draw := remaining
+		if draw > b.capacity {
+			draw = b.capacity
+		}
+		wait := b.reserve(draw)
+		if wait <= 0 {
+			remaining -= draw
+			continue
+		}
+		time.Sleep(wait)
+	}
+}
+
+// reserve refills the bucket based on elapsed time and either consumes
+// need tokens (returning 0) or reports how long the caller must wait
+// before retrying. need must not exceed the bucket's capacity.
+func (b *tokenBucket) reserve(need float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	elapsed := now.Sub(b.last)
+	b.last = now
+	b.tokens += elapsed.Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	if b.tokens >= need {
+		b.tokens -= need
+		return 0
+	}
+	shortfall := need - b.tokens
+	return time.Duration(shortfall / b.rate * float64(time.Second))
+}
@@ -0,0 +1,164 @@
+// Write-ahead log support for fill. Every message that is handed to
+// SendMessageBatch is recorded in the WAL before the batch is sent, and
+// again with its ack bit set once the batch send succeeds. If a fill is
+// interrupted, -replay can be used to resend only the messages that were
+// never acked, instead of restarting the whole fill.
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/crowdmob/goamz/sqs"
+)
+
+const defaultWALFsyncEvery = 50
+
+// walRecord is the unit of WAL framing: a length-prefixed JSON blob.
+// A record with Ack == false is logged just before a batch containing
+// that message is sent; the same record with Ack == true is logged once
+// the batch send is confirmed successful.
+type walRecord struct {
+	ID   string `json:"id"`
+	Body string `json:"body"`
+	TS   int64  `json:"ts"`
+	Ack  bool   `json:"ack"`
+}
+
+// walWriter serializes WAL appends from possibly many concurrent workers
+// through a single goroutine, so records from different workers never
+// interleave mid-frame. It fsyncs every fsyncEvery records.
+func walWriter(recs <-chan walRecord, f *os.File, fsyncEvery int, errCh chan<- error) {
+	w := bufio.NewWriter(f)
+	n := 0
+	for r := range recs {
+		if err := writeWALRecord(w, r); err != nil {
+			errCh <- err
+			continue
+		}
+		n++
+		if fsyncEvery > 0 && n%fsyncEvery == 0 {
+			if err := flushAndSync(w, f); err != nil {
+				errCh <- err
+			}
+		}
+	}
+	if err := flushAndSync(w, f); err != nil {
+		errCh <- err
+	}
+	close(errCh)
+}
+
+func flushAndSync(w *bufio.Writer, f *os.File) error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func writeWALRecord(w io.Writer, r walRecord) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(body)))
+	if _, err := w.Write(lenBuf[:n]); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func readWALRecord(r *bufio.Reader) (walRecord, error) {
+	var rec walRecord
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return rec, err
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return rec, err
+	}
+	err = json.Unmarshal(body, &rec)
+	return rec, err
+}
+
+// replayWAL reads the WAL at path, determines which messages were never
+// acked, and resends only those, appending fresh WAL entries for the
+// resend just like a normal fill would.
+func (c *fillConfig) replayWAL(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Println("Error opening WAL for replay:", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	acked := make(map[string]bool)
+	pending := make(map[string]string) // id -> body
+	r := bufio.NewReader(f)
+	for {
+		rec, err := readWALRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println("Error reading WAL record, stopping replay scan:", err)
+			break
+		}
+		if rec.Ack {
+			acked[rec.ID] = true
+			delete(pending, rec.ID)
+		} else {
+			pending[rec.ID] = rec.Body
+		}
+	}
+
+	if len(pending) == 0 {
+		fmt.Println("WAL replay: nothing to resend")
+		return
+	}
+
+	out, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Println("Error opening WAL for append during replay:", err)
+		os.Exit(1)
+	}
+	defer out.Close()
+
+	walCh := make(chan walRecord)
+	walErrCh := make(chan error)
+	walDone := make(chan struct{})
+	go walWriter(walCh, out, c.walFsyncEvery, walErrCh)
+	go func() {
+		for err := range walErrCh {
+			fmt.Println("WAL write error:", err)
+		}
+		close(walDone)
+	}()
+
+	msgs := make([]sqs.Message, 0, len(pending))
+	for id, body := range pending {
+		msgs = append(msgs, sqs.Message{MessageId: id, Body: body})
+	}
+
+	resent := 0
+	for len(msgs) > 0 {
+		batchSize := fillBatchSize
+		if len(msgs) < batchSize {
+			batchSize = len(msgs)
+		}
+		batch := msgs[:batchSize]
+		msgs = msgs[batchSize:]
+		c.sendBatchWithWAL(batch, walCh)
+		resent += len(batch)
+	}
+	close(walCh)
+	<-walDone
+	fmt.Printf("WAL replay: resent %d of %d unacked messages\n", resent, len(pending))
+}
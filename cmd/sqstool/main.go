@@ -0,0 +1,58 @@
+// sqstool is the unified successor to the old standalone sqsattr and
+// sqsfill binaries, plus drain, tail, move, and redrive. All subcommands
+// share pkg/sqsclient for auth, region normalization, queue lookup, and
+// retries instead of each reimplementing it.
+//
+// Usage:
+//
+//	sqstool attr    -q queue[,queue...] -r region[,region...|all] [-a attr[,attr...]] [-o table|json|csv]
+//	sqstool fill    -q queue -r region [-c count] [-gen ...] [-rate ...] [-wal ...] ...
+//	sqstool drain   -q queue -r region [-dry-run] [-to queue] [-max-messages n]
+//	sqstool tail    -q queue -r region
+//	sqstool move    -q queue -r region -to queue [-max-messages n]
+//	sqstool redrive -q queue -r region -to queue [-filter ...] [-transform ...] [-max-messages n]
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "attr":
+		runAttr(args)
+	case "fill":
+		runFill(args)
+	case "drain":
+		runDrain(args)
+	case "tail":
+		runTail(args)
+	case "move":
+		runMove(args)
+	case "redrive":
+		runRedrive(args)
+	case "-h", "-help", "--help", "help":
+		usage()
+	default:
+		fmt.Printf("sqstool: unknown subcommand %q\n\n", cmd)
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Println("Usage: sqstool <attr|fill|drain|tail|move|redrive> [flags]")
+}
+
+func fatal(err error) {
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,220 @@
+// The redrive subcommand moves messages from a source queue to a
+// destination queue, the common "fix the bug, now get the DLQ messages
+// back onto the main queue" workflow, with an optional filter to select
+// which messages qualify and an optional transform to rewrite their
+// bodies in flight.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"text/template"
+
+	"github.com/crowdmob/goamz/sqs"
+
+	"github.com/ianfoo/aws-tools/pkg/sqsclient"
+)
+
+const redriveBatchSize = 10
+
+type redriveConfig struct {
+	from, to          *sqs.Queue
+	filter            predicate
+	transform         *template.Template
+	maxMessages       int64
+	visibilityTimeout int
+
+	remaining int64 // atomic countdown when maxMessages > 0; unused otherwise
+}
+
+// predicate reports whether a message body should be redriven.
+type predicate func(body string) bool
+
+func runRedrive(args []string) {
+	fs := flag.NewFlagSet("redrive", flag.ExitOnError)
+	queueName := fs.String("q", "", "Name of source queue to redrive from")
+	toQueueName := fs.String("to", "", "Name of destination queue to redrive to")
+	region := fs.String("r", "", `Queue region (e.g., "us-east-1", "usw01")`)
+	filterExpr := fs.String("filter", "", `Only redrive messages matching this predicate: a plain substring, "regex:<pattern>", or "jsonpath:<dotted.path>=<value>"`)
+	transformSrc := fs.String("transform", "", "Go text/template source to rewrite each message body before redriving")
+	maxMessages := fs.Int64("max-messages", 0, "Stop after redriving this many messages (default: redrive until the source is empty)")
+	visibilityTimeout := fs.Int("visibility-timeout", 30, "Visibility timeout (seconds) for received messages")
+	workers := fs.Int("workers", 4, "Number of concurrent redrive workers")
+	fs.Parse(args)
+
+	if *queueName == "" || *toQueueName == "" || *region == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	filter, err := parsePredicate(*filterExpr)
+	fatal(err)
+	transform, err := parseTransform(*transformSrc)
+	fatal(err)
+
+	auth, err := sqsclient.Auth()
+	fatal(err)
+	client, err := sqsclient.New(auth, *region)
+	fatal(err)
+	from, err := client.GetQueue(*queueName)
+	fatal(err)
+	to, err := client.GetQueue(*toQueueName)
+	fatal(err)
+
+	cfg := &redriveConfig{
+		from:              from,
+		to:                to,
+		filter:            filter,
+		transform:         transform,
+		maxMessages:       *maxMessages,
+		visibilityTimeout: *visibilityTimeout,
+		remaining:         *maxMessages,
+	}
+
+	countCh := make(chan int64)
+	for i := 0; i < *workers; i++ {
+		go cfg.redriveWorker(countCh)
+	}
+	var total int64
+	for i := 0; i < *workers; i++ {
+		total += <-countCh
+	}
+	fmt.Printf("redrove %d messages from %s to %s\n", total, *queueName, *toQueueName)
+}
+
+// redriveWorker receives, filters, transforms, and forwards messages
+// until the source queue is empty or the shared max-messages budget is
+// exhausted, mirroring fillConcurrent's worker-per-goroutine pattern.
+// The budget is tracked against messages actually redriven, not against
+// receive attempts, so -filter and per-message failures don't cause it
+// to stop short of the queue actually being empty.
+func (c *redriveConfig) redriveWorker(reportCh chan<- int64) {
+	var moved int64
+	for {
+		if c.maxMessages > 0 && atomic.LoadInt64(&c.remaining) <= 0 {
+			break
+		}
+		resp, err := c.from.ReceiveMessageWithVisibilityTimeout(redriveBatchSize, c.visibilityTimeout)
+		if err != nil {
+			fmt.Println("Error receiving messages:", err)
+			break
+		}
+		if len(resp.Messages) == 0 {
+			break
+		}
+		for _, m := range resp.Messages {
+			if c.maxMessages > 0 && atomic.LoadInt64(&c.remaining) <= 0 {
+				break
+			}
+			if c.filter != nil && !c.filter(m.Body) {
+				continue
+			}
+			body, err := c.applyTransform(m)
+			if err != nil {
+				fmt.Printf("Error transforming message %s, leaving it on the source queue: %v\n", m.MessageId, err)
+				continue
+			}
+			// Claim one unit of the budget before sending, so concurrent
+			// workers cannot together redrive more than maxMessages; a
+			// failed redrive gives its unit back.
+			if c.maxMessages > 0 && atomic.AddInt64(&c.remaining, -1) < 0 {
+				atomic.AddInt64(&c.remaining, 1)
+				break
+			}
+			if _, err := c.to.SendMessageBatch([]sqs.Message{{MessageId: m.MessageId, Body: body}}); err != nil {
+				fmt.Printf("Error redriving message %s, leaving it on the source queue: %v\n", m.MessageId, err)
+				if c.maxMessages > 0 {
+					atomic.AddInt64(&c.remaining, 1)
+				}
+				continue
+			}
+			if _, err := c.from.DeleteMessage(&m); err != nil {
+				fmt.Printf("Error deleting message %s from source queue: %v\n", m.MessageId, err)
+				if c.maxMessages > 0 {
+					atomic.AddInt64(&c.remaining, 1)
+				}
+				continue
+			}
+			moved++
+		}
+	}
+	reportCh <- moved
+}
+
+func (c *redriveConfig) applyTransform(m sqs.Message) (string, error) {
+	if c.transform == nil {
+		return m.Body, nil
+	}
+	var buf strings.Builder
+	if err := c.transform.Execute(&buf, struct{ MessageId, Body string }{m.MessageId, m.Body}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func parseTransform(src string) (*template.Template, error) {
+	if src == "" {
+		return nil, nil
+	}
+	return template.New("transform").Parse(src)
+}
+
+// parsePredicate builds a predicate from -filter. A bare string is a
+// substring match; "regex:<pattern>" compiles pattern as a regular
+// expression; "jsonpath:<dotted.path>=<value>" decodes the body as JSON
+// and compares the string value at path.
+func parsePredicate(expr string) (predicate, error) {
+	switch {
+	case expr == "":
+		return nil, nil
+	case strings.HasPrefix(expr, "regex:"):
+		re, err := regexp.Compile(strings.TrimPrefix(expr, "regex:"))
+		if err != nil {
+			return nil, fmt.Errorf("compiling -filter regex: %s", err)
+		}
+		return re.MatchString, nil
+	case strings.HasPrefix(expr, "jsonpath:"):
+		path, want, err := splitJSONPathPredicate(strings.TrimPrefix(expr, "jsonpath:"))
+		if err != nil {
+			return nil, err
+		}
+		return func(body string) bool {
+			var doc map[string]interface{}
+			if json.Unmarshal([]byte(body), &doc) != nil {
+				return false
+			}
+			got, ok := lookupJSONPath(doc, path)
+			return ok && fmt.Sprint(got) == want
+		}, nil
+	default:
+		return func(body string) bool { return strings.Contains(body, expr) }, nil
+	}
+}
+
+func splitJSONPathPredicate(expr string) (path []string, want string, err error) {
+	parts := strings.SplitN(expr, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, "", fmt.Errorf(`-filter jsonpath predicate must look like "jsonpath:<dotted.path>=<value>"`)
+	}
+	return strings.Split(parts[0], "."), parts[1], nil
+}
+
+func lookupJSONPath(doc map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = doc
+	for _, field := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[field]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ianfoo/aws-tools/pkg/sqsclient"
+)
+
+const (
+	tailBatchSize = 10
+	tailWaitSecs  = 20
+)
+
+// runTail long-polls a queue (WaitTimeSeconds: tailWaitSecs) and prints
+// each message's body as it arrives. It never deletes anything, so the
+// same messages will show up again once their visibility timeout
+// expires.
+func runTail(args []string) {
+	fs := flag.NewFlagSet("tail", flag.ExitOnError)
+	queueName := fs.String("q", "", "Name of queue to tail")
+	region := fs.String("r", "", `Queue region (e.g., "us-east-1", "usw01")`)
+	pollInterval := fs.Int("poll-interval", 5, "Seconds to wait between polls when the queue is empty")
+	fs.Parse(args)
+
+	if *queueName == "" || *region == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	auth, err := sqsclient.Auth()
+	fatal(err)
+	client, err := sqsclient.New(auth, *region)
+	fatal(err)
+	q, err := client.GetQueue(*queueName)
+	fatal(err)
+
+	for {
+		resp, err := q.ReceiveMessageWithParameters(map[string]string{
+			"MaxNumberOfMessages": strconv.Itoa(tailBatchSize),
+			"VisibilityTimeout":   "0",
+			"WaitTimeSeconds":     strconv.Itoa(tailWaitSecs),
+		})
+		if err != nil {
+			fmt.Println("Error receiving messages:", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		for _, m := range resp.Messages {
+			fmt.Printf("[%s] %s\n", m.MessageId, m.Body)
+		}
+		if len(resp.Messages) == 0 {
+			time.Sleep(time.Duration(*pollInterval) * time.Second)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package sqsclient is the shared plumbing behind sqstool's
+// subcommands: AWS auth, the region-shorthand normalization this module
+// has always supported, queue lookup, and retries with exponential
+// backoff and jitter so a transient API error doesn't kill a whole run.
+package sqsclient
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/crowdmob/goamz/aws"
+	"github.com/crowdmob/goamz/sqs"
+)
+
+const (
+	maxRetries  = 5
+	baseBackoff = 100 * time.Millisecond
+	maxBackoff  = 10 * time.Second
+)
+
+// AllRegions is the region set "-r all" fans out across.
+var AllRegions = []string{
+	"us-east-1",
+	"us-west-1",
+	"us-west-2",
+	"ap-northeast-1",
+	"sa-east-1",
+}
+
+// Client wraps an authenticated SQS service handle for a single region.
+type Client struct {
+	Region string
+	svc    *sqs.SQS
+}
+
+// Auth resolves AWS credentials with aws.GetAuth: environment
+// variables first, then falling back to EC2
+// instance-role credentials and the ~/.aws credentials file.
+func Auth() (aws.Auth, error) {
+	return aws.GetAuth("", "", "", time.Time{})
+}
+
+// New creates a Client for region, after normalizing any of this
+// module's region shorthands (e.g. "usw01") to their real AWS names.
+func New(auth aws.Auth, region string) (*Client, error) {
+	region = NormalizeRegion(region)
+	svc, err := sqs.NewFrom(auth.AccessKey, auth.SecretKey, region)
+	if err != nil {
+		return nil, fmt.Errorf("accessing SQS in %s: %s", region, err)
+	}
+	return &Client{Region: region, svc: svc}, nil
+}
+
+// NormalizeRegion expands this module's long-standing region
+// shorthands into the region names the SQS API expects.
+func NormalizeRegion(region string) string {
+	switch {
+	case region == "use01" || region == "use":
+		return "us-east-1"
+	case region == "usw01" || region == "usw" || region == "usw1":
+		return "us-west-1"
+	case region == "usw02" || region == "usw2":
+		return "us-west-2"
+	case region == "apn01" || region == "apn":
+		return "ap-northeast-1"
+	case region == "sae01" || region == "sae":
+		return "sa-east-1"
+	default:
+		return region
+	}
+}
+
+// GetQueue looks up name, retrying transient failures with exponential
+// backoff and jitter.
+func (c *Client) GetQueue(name string) (*sqs.Queue, error) {
+	var (
+		q   *sqs.Queue
+		err error
+	)
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		q, err = c.svc.GetQueue(name)
+		if err == nil {
+			return q, nil
+		}
+		time.Sleep(backoff(attempt))
+	}
+	return nil, err
+}
+
+// backoff returns an exponential delay for attempt, jittered by up to
+// half its value so a batch of retrying callers doesn't resync.
+func backoff(attempt int) time.Duration {
+	d := time.Duration(float64(baseBackoff) * math.Pow(2, float64(attempt)))
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(d)/2 + 1))
+	return d/2 + jitter
+}